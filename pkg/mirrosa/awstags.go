@@ -0,0 +1,16 @@
+package mirrosa
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// ec2TagsToMap flattens the []types.Tag shape every EC2 Describe* response
+// uses into a plain map, which is what tags.ValidateTags expects.
+func ec2TagsToMap(ec2Tags []types.Tag) map[string]string {
+	m := make(map[string]string, len(ec2Tags))
+	for _, t := range ec2Tags {
+		m[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return m
+}
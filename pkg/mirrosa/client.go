@@ -0,0 +1,91 @@
+package mirrosa
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"go.uber.org/zap"
+)
+
+// ClusterInfo holds the handful of cluster properties mirrosa's Components
+// key their AWS lookups off of, pulled out of the OCM Cluster object once up
+// front rather than re-derived by every Component.
+type ClusterInfo struct {
+	InfraName string
+	APIURL    string
+}
+
+// Client is the shared entry point for every mirrosa Component. It holds
+// the AWS credentials and cluster context a Component needs to find and
+// validate the infrastructure backing a ROSA cluster.
+type Client struct {
+	log *zap.SugaredLogger
+
+	Cluster     *cmv1.Cluster
+	ClusterInfo ClusterInfo
+
+	// HiveAccountID is the AWS account ID of the management/Hive account
+	// that owns the client side of a PrivateLink connection. Components
+	// use it to tell an expected Hive-initiated VPC Endpoint connection
+	// apart from an unexpected one.
+	HiveAccountID string
+
+	// HiveVpcId, HiveSubnetIds, and HiveSecurityGroupIds describe the
+	// Hive-account network the client-side VPC Endpoint is expected to
+	// sit in. VpcEndpoint cross-checks the endpoint it finds against
+	// these so a PrivateLink connection that's technically "available"
+	// but was recreated in the wrong VPC/subnet/security group doesn't
+	// pass silently.
+	HiveVpcId            string
+	HiveSubnetIds        []string
+	HiveSecurityGroupIds []string
+
+	// AwsConfig is used to build clients that operate against the
+	// cluster's own AWS account.
+	AwsConfig aws.Config
+
+	// HiveAwsConfig is used to build clients that operate against the
+	// management/Hive account, e.g. to look up the client-side VPC
+	// Endpoint that Hive owns.
+	HiveAwsConfig aws.Config
+
+	// AllowRemediation gates whether any Component's Remediate is
+	// permitted to make changes. It defaults to false so running
+	// mirrosa is always safe unless an operator opts in.
+	AllowRemediation bool
+}
+
+// NewClient returns a Client for the given cluster, ready to construct
+// Components from.
+func NewClient(log *zap.SugaredLogger, cluster *cmv1.Cluster, clusterInfo ClusterInfo, awsConfig, hiveAwsConfig aws.Config) *Client {
+	return &Client{
+		log:           log,
+		Cluster:       cluster,
+		ClusterInfo:   clusterInfo,
+		AwsConfig:     awsConfig,
+		HiveAwsConfig: hiveAwsConfig,
+	}
+}
+
+// ValidatePrivateLink validates the service-side and client-side halves of
+// a cluster's PrivateLink connection in sequence, feeding what
+// VpcEndpointService discovers into VpcEndpoint's expectations before it
+// runs. VpcEndpoint has no way to know the service-id the cluster's own
+// account is serving on - only VpcEndpointService, which looks in a
+// different AWS account, can tell it.
+func (c *Client) ValidatePrivateLink(ctx context.Context) (serviceResult, endpointResult ComponentResult, err error) {
+	svc := c.NewVpcEndpointService()
+	serviceResult, err = svc.Validate(ctx)
+	if err != nil {
+		return serviceResult, ComponentResult{}, err
+	}
+
+	endpoint := c.NewVpcEndpoint()
+	endpoint.ExpectedServiceId = svc.ServiceId
+	endpoint.ExpectedPrivateDnsName = svc.PrivateDnsName
+	endpoint.ExpectedBaseEndpointDnsNames = svc.BaseEndpointDnsNames
+
+	endpointResult, err = endpoint.Validate(ctx)
+	return serviceResult, endpointResult, err
+}
@@ -0,0 +1,90 @@
+package mirrosa
+
+import (
+	"context"
+	"fmt"
+)
+
+// Component is a discrete piece of AWS (or other cloud) infrastructure that
+// backs a ROSA cluster. Each Component knows how to find itself, confirm it
+// is configured the way ROSA expects, and describe itself to a human.
+type Component interface {
+	// Validate confirms that the Component exists and is configured
+	// correctly, returning a ComponentResult describing the outcome.
+	Validate(ctx context.Context) (ComponentResult, error)
+
+	// Documentation returns a human-readable explanation of what this
+	// Component is and why mirrosa checks it.
+	Documentation() string
+
+	// FilterValue satisfies bubbles/list.Item so Components can be
+	// rendered and filtered in the interactive TUI.
+	FilterValue() string
+}
+
+// Remediator is implemented by Components that know how to fix a problem
+// Validate found, rather than just reporting it. Not every Component
+// supports this, so it's a separate interface - callers type-assert a
+// Component against it before calling Remediate:
+//
+//	if r, ok := component.(Remediator); ok {
+//	    err = r.Remediate(ctx)
+//	}
+type Remediator interface {
+	// Remediate attempts to fix whatever Validate found to be wrong. A
+	// Remediator must refuse to act unless remediation has been enabled,
+	// both at the Client level (Client.AllowRemediation) and by the
+	// component itself, so that constructing a Component is never by
+	// itself enough to make it mutate anything.
+	Remediate(ctx context.Context) error
+}
+
+// ComponentResult is the structured outcome of validating a single
+// Component. It replaces a bare error so that callers - and the TUI - can
+// report exactly which sub-check failed instead of collapsing everything
+// into pass/fail.
+type ComponentResult struct {
+	// Name identifies the Component this result belongs to, e.g. "VPC
+	// Endpoint Service".
+	Name string
+
+	// Valid is true only if every check this Component performs passed.
+	Valid bool
+
+	// Errors holds one entry per failed check. Valid is false whenever
+	// Errors is non-empty.
+	Errors []error
+}
+
+// NewComponentResult returns a ComponentResult for the given Component name,
+// initialized as valid. Use AddError to record failures as checks run.
+func NewComponentResult(name string) ComponentResult {
+	return ComponentResult{
+		Name:  name,
+		Valid: true,
+	}
+}
+
+// AddError records a failed check against the result, marking it invalid.
+// A nil err is a no-op so callers can pass through the result of a check
+// without an extra if-statement.
+func (c *ComponentResult) AddError(err error) {
+	if err == nil {
+		return
+	}
+	c.Valid = false
+	c.Errors = append(c.Errors, err)
+}
+
+// Error implements the error interface so a ComponentResult can still be
+// returned/handled anywhere a plain error was expected.
+func (c ComponentResult) Error() string {
+	if c.Valid {
+		return ""
+	}
+	msg := fmt.Sprintf("%s: %d validation error(s)", c.Name, len(c.Errors))
+	for _, err := range c.Errors {
+		msg += fmt.Sprintf("\n  - %s", err)
+	}
+	return msg
+}
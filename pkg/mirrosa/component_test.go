@@ -0,0 +1,40 @@
+package mirrosa
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComponentResultAddError(t *testing.T) {
+	result := NewComponentResult("Test Component")
+	if !result.Valid {
+		t.Fatal("expected a fresh ComponentResult to be valid")
+	}
+
+	result.AddError(nil)
+	if !result.Valid || len(result.Errors) != 0 {
+		t.Fatal("AddError(nil) should be a no-op")
+	}
+
+	result.AddError(errors.New("boom"))
+	if result.Valid {
+		t.Fatal("expected ComponentResult to be invalid after AddError")
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %d", len(result.Errors))
+	}
+}
+
+func TestComponentResultError(t *testing.T) {
+	result := NewComponentResult("Test Component")
+	if got := result.Error(); got != "" {
+		t.Fatalf("expected empty Error() for a valid result, got %q", got)
+	}
+
+	result.AddError(errors.New("first"))
+	result.AddError(errors.New("second"))
+	got := result.Error()
+	if got == "" {
+		t.Fatal("expected a non-empty Error() for an invalid result")
+	}
+}
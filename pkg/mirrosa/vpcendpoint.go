@@ -0,0 +1,382 @@
+package mirrosa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"go.uber.org/zap"
+
+	"github.com/openshift/mirrosa/pkg/tags"
+)
+
+const vpcEndpointDescription = "A PrivateLink ROSA cluster has a VPC Endpoint in Hive's own account, the client" +
+	" side of the PrivateLink connection, which is what Hive actually dials to reach the cluster's API server."
+
+var _ Component = &VpcEndpoint{}
+
+// VpcEndpoint is the client-side (Hive-account-owned) half of the
+// PrivateLink connection between Hive and a ROSA cluster. It mirrors
+// VpcEndpointService, which is the service-side (cluster-account-owned)
+// half, and cross-checks that the two agree on which service they're
+// talking about.
+type VpcEndpoint struct {
+	log         *zap.SugaredLogger
+	InfraName   string
+	PrivateLink bool
+
+	// ExpectedServiceId, when set, is cross-checked against the
+	// service-id the VPC Endpoint reports it is connected to. It is
+	// normally populated from a VpcEndpointService that has already run.
+	ExpectedServiceId string
+
+	// ExpectedPrivateDnsName and ExpectedBaseEndpointDnsNames, when set,
+	// are cross-checked against the DNS names on the VPC Endpoint's own
+	// DnsEntries. They are normally populated from a VpcEndpointService
+	// that has already run, since only the service side's configuration
+	// knows what DNS names the cluster was set up to advertise.
+	ExpectedPrivateDnsName       string
+	ExpectedBaseEndpointDnsNames []string
+
+	// ExpectedVpcId, ExpectedSubnetIds, and ExpectedSecurityGroupIds, when
+	// set, are cross-checked against the VPC Endpoint's own VpcId,
+	// SubnetIds, and Groups. They are normally populated from Client's
+	// Hive* network fields, since only the operator configuring mirrosa
+	// knows which Hive VPC/subnets/security groups the endpoint should
+	// have been created in.
+	ExpectedVpcId            string
+	ExpectedSubnetIds        []string
+	ExpectedSecurityGroupIds []string
+
+	// APIURL is the cluster's API server URL, taken from ClusterInfo. Its
+	// hostname is what Validate resolves to confirm PrivateLink DNS is
+	// actually wired up, rather than just present in configuration.
+	APIURL string
+
+	// ResolverAddr, when set, points hostname resolution at a specific
+	// DNS server (host:port) instead of the system resolver - typically
+	// the VPC's reserved ".2" resolver, so this check reflects what the
+	// cluster's own VPC sees rather than whatever DNS the operator
+	// running mirrosa happens to have.
+	ResolverAddr string
+
+	// PrivateHostedZoneId is the cluster's private hosted zone, taken
+	// from Cluster.AWS().PrivateHostedZoneID(). This is the zone that
+	// actually serves the API record - it is not derivable from the VPC
+	// Endpoint's own DnsEntries, which carry the AWS-managed zone for the
+	// endpoint itself, not the cluster's private zone.
+	PrivateHostedZoneId string
+
+	// HiveEc2Client talks to EC2 in the management/Hive account, where
+	// the client-side endpoint lives, as opposed to Ec2Client on
+	// VpcEndpointService which talks to the cluster's own account.
+	HiveEc2Client Ec2AwsApi
+
+	// Route53Client looks up the private hosted zone record set that
+	// should back the cluster's API hostname.
+	Route53Client Route53AwsApi
+}
+
+func (c *Client) NewVpcEndpoint() *VpcEndpoint {
+	return &VpcEndpoint{
+		log:                      c.log,
+		InfraName:                c.ClusterInfo.InfraName,
+		PrivateLink:              c.Cluster.AWS().PrivateLink(),
+		APIURL:                   c.ClusterInfo.APIURL,
+		ExpectedVpcId:            c.HiveVpcId,
+		ExpectedSubnetIds:        c.HiveSubnetIds,
+		ExpectedSecurityGroupIds: c.HiveSecurityGroupIds,
+		PrivateHostedZoneId:      c.Cluster.AWS().PrivateHostedZoneID(),
+		HiveEc2Client:            ec2.NewFromConfig(c.HiveAwsConfig),
+		Route53Client:            route53.NewFromConfig(c.AwsConfig),
+	}
+}
+
+func (v *VpcEndpoint) Validate(ctx context.Context) (ComponentResult, error) {
+	result := NewComponentResult(v.FilterValue())
+
+	// non-PrivateLink clusters do not have a VPC Endpoint
+	if !v.PrivateLink {
+		return result, nil
+	}
+
+	v.log.Info("searching for VPC Endpoint in Hive account")
+	resp, err := v.HiveEc2Client.DescribeVpcEndpoints(ctx, &ec2.DescribeVpcEndpointsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("tag:Name"),
+				Values: []string{fmt.Sprintf("%s-vpc-endpoint", v.InfraName)},
+			},
+			{
+				Name:   aws.String("tag:hive.openshift.io/private-link-access-for"),
+				Values: []string{v.InfraName},
+			},
+		},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	var endpoint types.VpcEndpoint
+	switch len(resp.VpcEndpoints) {
+	case 0:
+		result.AddError(errors.New("no VPC Endpoints found for PrivateLink cluster"))
+		return result, nil
+	case 1:
+		endpoint = resp.VpcEndpoints[0]
+		v.log.Infof("found VPC Endpoint: %s", aws.ToString(endpoint.VpcEndpointId))
+		if diag := tags.ValidateTags(tags.KindVpcEndpoint, ec2TagsToMap(endpoint.Tags), v.InfraName); !diag.Valid() {
+			result.AddError(diag)
+		}
+	default:
+		result.AddError(errors.New("multiple VPC Endpoints found for PrivateLink cluster"))
+		return result, nil
+	}
+
+	if v.ExpectedServiceId != "" && aws.ToString(endpoint.ServiceName) != "" &&
+		!strings.HasSuffix(aws.ToString(endpoint.ServiceName), v.ExpectedServiceId) {
+		result.AddError(fmt.Errorf("VPC Endpoint %s is connected to service %s, expected %s",
+			aws.ToString(endpoint.VpcEndpointId), aws.ToString(endpoint.ServiceName), v.ExpectedServiceId))
+	}
+
+	byState := make(map[types.State][]string)
+	byState[endpoint.State] = append(byState[endpoint.State], aws.ToString(endpoint.VpcEndpointId))
+	v.log.Infof("VPC Endpoint %s state: %s", aws.ToString(endpoint.VpcEndpointId), endpoint.State)
+
+	if endpoint.State != types.StateAvailable {
+		result.AddError(fmt.Errorf("VPC Endpoint %s is in state %q, expected %q",
+			aws.ToString(endpoint.VpcEndpointId), endpoint.State, types.StateAvailable))
+	}
+
+	result.AddError(v.validateNetworking(endpoint))
+	result.AddError(v.validateDns(ctx, endpoint))
+
+	return result, nil
+}
+
+// validateNetworking cross-checks the VPC Endpoint's VPC, subnets, and
+// security groups against the Hive network mirrosa was configured to
+// expect. Without it, an endpoint recreated in the wrong VPC, in an
+// unexpected subnet, or with a security group outside the expected set
+// would still read as "available". An expected subnet or security group
+// that the endpoint lacks is not reported.
+func (v *VpcEndpoint) validateNetworking(endpoint types.VpcEndpoint) error {
+	id := aws.ToString(endpoint.VpcEndpointId)
+
+	if v.ExpectedVpcId != "" && aws.ToString(endpoint.VpcId) != v.ExpectedVpcId {
+		return fmt.Errorf("VPC Endpoint %s is in VPC %s, expected %s",
+			id, aws.ToString(endpoint.VpcId), v.ExpectedVpcId)
+	}
+
+	if len(v.ExpectedSubnetIds) > 0 {
+		if unexpected := subtract(endpoint.SubnetIds, v.ExpectedSubnetIds); len(unexpected) > 0 {
+			return fmt.Errorf("VPC Endpoint %s is in unexpected subnet(s) %v, expected one of %v",
+				id, unexpected, v.ExpectedSubnetIds)
+		}
+	}
+
+	if len(v.ExpectedSecurityGroupIds) > 0 {
+		var groupIds []string
+		for _, g := range endpoint.Groups {
+			groupIds = append(groupIds, aws.ToString(g.GroupId))
+		}
+		if unexpected := subtract(groupIds, v.ExpectedSecurityGroupIds); len(unexpected) > 0 {
+			return fmt.Errorf("VPC Endpoint %s has unexpected security group(s) %v, expected one of %v",
+				id, unexpected, v.ExpectedSecurityGroupIds)
+		}
+	}
+
+	return nil
+}
+
+// validateDns confirms the endpoint's own DNS entries agree with what
+// VpcEndpointService's configuration advertised, that the API server's
+// hostname actually resolves to one of the VPC Endpoint's ENIs, and that
+// the cluster's private hosted zone has the record set that makes that
+// resolution possible. This is the runtime check behind the
+// dns_entry/private_dns_name attributes the endpoint exposes: a DNS entry
+// existing doesn't mean it's propagated.
+func (v *VpcEndpoint) validateDns(ctx context.Context, endpoint types.VpcEndpoint) error {
+	if v.APIURL == "" {
+		return errors.New("cannot validate PrivateLink DNS without a cluster API URL")
+	}
+	if len(endpoint.DnsEntries) == 0 {
+		return fmt.Errorf("VPC Endpoint %s has no DNS entries", aws.ToString(endpoint.VpcEndpointId))
+	}
+
+	if err := v.validateExpectedDnsNames(endpoint); err != nil {
+		return err
+	}
+
+	apiHost, err := apiURLHostname(v.APIURL)
+	if err != nil {
+		return err
+	}
+
+	eniIPs, err := v.endpointNetworkInterfaceIPs(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	resolver := &net.Resolver{}
+	if v.ResolverAddr != "" {
+		resolver.PreferGo = true
+		resolver.Dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, v.ResolverAddr)
+		}
+	}
+
+	resolved, err := resolver.LookupHost(ctx, apiHost)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", apiHost, err)
+	}
+
+	if !anyIntersect(resolved, eniIPs) {
+		return fmt.Errorf("%s resolves to %v, none of which are VPC Endpoint %s's ENI IPs %v",
+			apiHost, resolved, aws.ToString(endpoint.VpcEndpointId), eniIPs)
+	}
+
+	return v.validateHostedZoneRecord(ctx, apiHost)
+}
+
+// validateHostedZoneRecord confirms the cluster's private hosted zone - not
+// the VPC Endpoint's own AWS-managed zone, which DnsEntries carries and
+// never contains the API record - actually has a record set for apiHost.
+func (v *VpcEndpoint) validateHostedZoneRecord(ctx context.Context, apiHost string) error {
+	if v.PrivateHostedZoneId == "" {
+		return errors.New("cannot validate the private hosted zone record without a PrivateHostedZoneId")
+	}
+
+	resp, err := v.Route53Client.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(v.PrivateHostedZoneId),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, rrs := range resp.ResourceRecordSets {
+		name := strings.TrimSuffix(aws.ToString(rrs.Name), ".")
+		if name == apiHost && (rrs.Type == route53types.RRTypeA || rrs.Type == route53types.RRTypeCname || rrs.AliasTarget != nil) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("private hosted zone %s has no record set for %s", v.PrivateHostedZoneId, apiHost)
+}
+
+// validateExpectedDnsNames cross-checks the DNS names on the VPC Endpoint
+// against what VpcEndpointService's configuration advertised, when that
+// information has been supplied. Without it this only confirms *a* DNS
+// entry exists, not that it's the one the cluster's service is meant to
+// publish.
+func (v *VpcEndpoint) validateExpectedDnsNames(endpoint types.VpcEndpoint) error {
+	if v.ExpectedPrivateDnsName == "" && len(v.ExpectedBaseEndpointDnsNames) == 0 {
+		return nil
+	}
+
+	var actual []string
+	for _, e := range endpoint.DnsEntries {
+		actual = append(actual, aws.ToString(e.DnsName))
+	}
+
+	if v.ExpectedPrivateDnsName != "" && !contains(actual, v.ExpectedPrivateDnsName) {
+		return fmt.Errorf("VPC Endpoint %s's DNS entries %v do not include the expected private DNS name %q",
+			aws.ToString(endpoint.VpcEndpointId), actual, v.ExpectedPrivateDnsName)
+	}
+
+	if len(v.ExpectedBaseEndpointDnsNames) > 0 && !anyIntersect(actual, v.ExpectedBaseEndpointDnsNames) {
+		return fmt.Errorf("VPC Endpoint %s's DNS entries %v match none of the service's base endpoint DNS names %v",
+			aws.ToString(endpoint.VpcEndpointId), actual, v.ExpectedBaseEndpointDnsNames)
+	}
+
+	return nil
+}
+
+// endpointNetworkInterfaceIPs returns the private IPv4 addresses of the
+// VPC Endpoint's ENIs, one per AZ the endpoint was created in.
+func (v *VpcEndpoint) endpointNetworkInterfaceIPs(ctx context.Context, endpoint types.VpcEndpoint) ([]string, error) {
+	if len(endpoint.NetworkInterfaceIds) == 0 {
+		return nil, fmt.Errorf("VPC Endpoint %s has no network interfaces", aws.ToString(endpoint.VpcEndpointId))
+	}
+
+	resp, err := v.HiveEc2Client.DescribeNetworkInterfaces(ctx, &ec2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: endpoint.NetworkInterfaceIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, eni := range resp.NetworkInterfaces {
+		ips = append(ips, aws.ToString(eni.PrivateIpAddress))
+	}
+	return ips, nil
+}
+
+// apiURLHostname extracts the hostname mirrosa should resolve from a
+// cluster API URL, e.g. "https://api.foo.example.com:6443" -> "api.foo.example.com".
+func apiURLHostname(apiURL string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing cluster API URL %q: %w", apiURL, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("cluster API URL %q has no hostname", apiURL)
+	}
+	return u.Hostname(), nil
+}
+
+// contains reports whether s holds v.
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// subtract returns the elements of a that are not present in b.
+func subtract(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+	var diff []string
+	for _, s := range a {
+		if _, ok := set[s]; !ok {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+// anyIntersect reports whether a and b share at least one element.
+func anyIntersect(a, b []string) bool {
+	set := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+	for _, s := range a {
+		if _, ok := set[s]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (v VpcEndpoint) Documentation() string {
+	return vpcEndpointDescription
+}
+
+func (v VpcEndpoint) FilterValue() string {
+	return "VPC Endpoint"
+}
@@ -0,0 +1,389 @@
+package mirrosa
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	route53types "github.com/aws/aws-sdk-go-v2/service/route53/types"
+	"go.uber.org/zap"
+)
+
+// fakeHiveEc2Api embeds Ec2AwsApi for the same reason fakeEc2Api does in
+// vpcendpointservice_test.go - it stands in for HiveEc2Client.
+type fakeHiveEc2Api struct {
+	Ec2AwsApi
+
+	describeVpcEndpointsOutput      *ec2.DescribeVpcEndpointsOutput
+	describeNetworkInterfacesOutput *ec2.DescribeNetworkInterfacesOutput
+}
+
+func (f *fakeHiveEc2Api) DescribeVpcEndpoints(_ context.Context, _ *ec2.DescribeVpcEndpointsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcEndpointsOutput, error) {
+	return f.describeVpcEndpointsOutput, nil
+}
+
+func (f *fakeHiveEc2Api) DescribeNetworkInterfaces(_ context.Context, _ *ec2.DescribeNetworkInterfacesInput, _ ...func(*ec2.Options)) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	if f.describeNetworkInterfacesOutput == nil {
+		return &ec2.DescribeNetworkInterfacesOutput{}, nil
+	}
+	return f.describeNetworkInterfacesOutput, nil
+}
+
+// fakeRoute53Api embeds Route53AwsApi so tests only need to implement
+// ListResourceRecordSets.
+type fakeRoute53Api struct {
+	Route53AwsApi
+
+	listResourceRecordSetsOutput *route53.ListResourceRecordSetsOutput
+}
+
+func (f *fakeRoute53Api) ListResourceRecordSets(_ context.Context, _ *route53.ListResourceRecordSetsInput, _ ...func(*route53.Options)) (*route53.ListResourceRecordSetsOutput, error) {
+	if f.listResourceRecordSetsOutput == nil {
+		return &route53.ListResourceRecordSetsOutput{}, nil
+	}
+	return f.listResourceRecordSetsOutput, nil
+}
+
+// startFakeDNSResolver runs a minimal UDP DNS server that answers every A
+// query with ip, so validateDns can exercise a real net.Resolver lookup
+// without reaching out to an actual DNS server. It returns the "host:port"
+// address to point VpcEndpoint.ResolverAddr at.
+func startFakeDNSResolver(t *testing.T, ip string) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake DNS resolver: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			resp, err := dnsAResponse(buf[:n], ip)
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// dnsAResponse builds a minimal DNS response answering the first question
+// in query. It only ever answers type-A (IPv4) questions with ip; any other
+// question type (e.g. the AAAA query LookupHost issues alongside A) gets an
+// empty, successful answer so the resolver doesn't treat the whole lookup
+// as failed. Any additional records on the query (e.g. the EDNS0 OPT
+// pseudo-record Go's resolver attaches) are ignored rather than echoed back.
+func dnsAResponse(query []byte, ip string) ([]byte, error) {
+	if len(query) < 12 {
+		return nil, net.InvalidAddrError("short query")
+	}
+
+	// Walk the QNAME's length-prefixed labels to find where it ends.
+	i := 12
+	for i < len(query) && query[i] != 0 {
+		i += int(query[i]) + 1
+	}
+	qnameEnd := i + 1
+	if qnameEnd+4 > len(query) {
+		return nil, net.InvalidAddrError("truncated question")
+	}
+	question := query[12 : qnameEnd+4] // QNAME + QTYPE + QCLASS, nothing else
+	qtype := binary.BigEndian.Uint16(query[qnameEnd : qnameEnd+2])
+
+	var answers []byte
+	ancount := 0
+	if qtype == 1 { // A
+		answers = append(answers, 0xC0, 0x0C) // NAME: pointer to offset 12
+		answers = append(answers, 0, 1)       // TYPE=A
+		answers = append(answers, 0, 1)       // CLASS=IN
+		ttl := make([]byte, 4)
+		binary.BigEndian.PutUint32(ttl, 60)
+		answers = append(answers, ttl...)
+		answers = append(answers, 0, 4) // RDLENGTH=4
+		answers = append(answers, net.ParseIP(ip).To4()...)
+		ancount = 1
+	}
+
+	resp := make([]byte, 0, 12+len(question)+len(answers))
+	resp = append(resp, query[0], query[1]) // ID
+	resp = append(resp, 0x81, 0x80)         // standard response, no error
+	resp = append(resp, 0, 1)               // QDCOUNT=1
+	resp = append(resp, byte(ancount>>8), byte(ancount))
+	resp = append(resp, 0, 0) // NSCOUNT=0
+	resp = append(resp, 0, 0) // ARCOUNT=0
+	resp = append(resp, question...)
+	resp = append(resp, answers...)
+
+	return resp, nil
+}
+
+// newTestVpcEndpoint returns a VpcEndpoint with no APIURL, so Validate's DNS
+// checks fail fast with a deterministic error instead of reaching out to a
+// real resolver. Tests that exercise validateDns set APIURL (and
+// ResolverAddr) explicitly.
+func newTestVpcEndpoint(hiveEc2 Ec2AwsApi, route53Client Route53AwsApi) *VpcEndpoint {
+	return &VpcEndpoint{
+		log:                 zap.NewNop().Sugar(),
+		InfraName:           "foo",
+		PrivateLink:         true,
+		PrivateHostedZoneId: "Z123",
+		HiveEc2Client:       hiveEc2,
+		Route53Client:       route53Client,
+	}
+}
+
+func availableTestVpcEndpoint() types.VpcEndpoint {
+	return types.VpcEndpoint{
+		VpcEndpointId:       aws.String("vpce-1"),
+		State:               types.StateAvailable,
+		ServiceName:         aws.String("com.amazonaws.vpce.us-east-1.vpce-svc-test"),
+		VpcId:               aws.String("vpc-1"),
+		SubnetIds:           []string{"subnet-1"},
+		Groups:              []types.SecurityGroupIdentifier{{GroupId: aws.String("sg-1")}},
+		NetworkInterfaceIds: []string{"eni-1"},
+		Tags: []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String("foo-vpc-endpoint")},
+			{Key: aws.String("red-hat-managed"), Value: aws.String("true")},
+			{Key: aws.String("hive.openshift.io/private-link-access-for"), Value: aws.String("foo")},
+			{Key: aws.String("kubernetes.io/cluster/foo"), Value: aws.String("owned")},
+		},
+		DnsEntries: []types.DnsEntry{
+			{DnsName: aws.String("api.foo.example.com"), HostedZoneId: aws.String("Z-VPCE")},
+		},
+	}
+}
+
+func TestVpcEndpointValidateNoPrivateLink(t *testing.T) {
+	v := newTestVpcEndpoint(&fakeHiveEc2Api{}, &fakeRoute53Api{})
+	v.PrivateLink = false
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("expected a valid result for a non-PrivateLink cluster, got %s", result.Error())
+	}
+}
+
+func TestVpcEndpointValidateNoEndpointsFound(t *testing.T) {
+	v := newTestVpcEndpoint(&fakeHiveEc2Api{describeVpcEndpointsOutput: &ec2.DescribeVpcEndpointsOutput{}}, &fakeRoute53Api{})
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when no VPC Endpoints are found")
+	}
+}
+
+func TestVpcEndpointValidateMultipleEndpointsFound(t *testing.T) {
+	hive := &fakeHiveEc2Api{
+		describeVpcEndpointsOutput: &ec2.DescribeVpcEndpointsOutput{
+			VpcEndpoints: []types.VpcEndpoint{availableTestVpcEndpoint(), availableTestVpcEndpoint()},
+		},
+	}
+	v := newTestVpcEndpoint(hive, &fakeRoute53Api{})
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when multiple VPC Endpoints are found")
+	}
+}
+
+func TestVpcEndpointValidateServiceIdMismatch(t *testing.T) {
+	hive := &fakeHiveEc2Api{
+		describeVpcEndpointsOutput: &ec2.DescribeVpcEndpointsOutput{
+			VpcEndpoints: []types.VpcEndpoint{availableTestVpcEndpoint()},
+		},
+	}
+	v := newTestVpcEndpoint(hive, &fakeRoute53Api{})
+	v.ExpectedServiceId = "vpce-svc-other"
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when the connected service-id doesn't match ExpectedServiceId")
+	}
+	if !anyErrorContains(result.Errors, "vpce-svc-other") {
+		t.Fatalf("expected an error naming the expected service-id, got %s", result.Error())
+	}
+}
+
+// anyErrorContains reports whether any of errs has substr in its message.
+// newTestVpcEndpoint leaves APIURL empty, so every Validate result carries a
+// DNS error; tests use this to check the error they're actually about.
+func anyErrorContains(errs []error, substr string) bool {
+	for _, err := range errs {
+		if strings.Contains(err.Error(), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestVpcEndpointValidateNetworkingMismatch(t *testing.T) {
+	hive := &fakeHiveEc2Api{
+		describeVpcEndpointsOutput: &ec2.DescribeVpcEndpointsOutput{
+			VpcEndpoints: []types.VpcEndpoint{availableTestVpcEndpoint()},
+		},
+	}
+	v := newTestVpcEndpoint(hive, &fakeRoute53Api{})
+	v.ExpectedVpcId = "vpc-other"
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when the endpoint's VpcId doesn't match ExpectedVpcId")
+	}
+	if !anyErrorContains(result.Errors, "vpc-other") {
+		t.Fatalf("expected an error naming the expected VPC, got %s", result.Error())
+	}
+}
+
+func TestVpcEndpointValidateEndToEndSuccess(t *testing.T) {
+	endpoint := availableTestVpcEndpoint()
+	hive := &fakeHiveEc2Api{
+		describeVpcEndpointsOutput: &ec2.DescribeVpcEndpointsOutput{
+			VpcEndpoints: []types.VpcEndpoint{endpoint},
+		},
+		describeNetworkInterfacesOutput: &ec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: []types.NetworkInterface{
+				{PrivateIpAddress: aws.String("10.0.0.10")},
+			},
+		},
+	}
+	r53 := &fakeRoute53Api{
+		listResourceRecordSetsOutput: &route53.ListResourceRecordSetsOutput{
+			ResourceRecordSets: []route53types.ResourceRecordSet{
+				{Name: aws.String("api.foo.example.com."), Type: route53types.RRTypeA},
+			},
+		},
+	}
+
+	v := newTestVpcEndpoint(hive, r53)
+	v.APIURL = "https://api.foo.example.com:6443"
+	v.ExpectedServiceId = "vpce-svc-test"
+	v.ExpectedVpcId = "vpc-1"
+	v.ExpectedSubnetIds = []string{"subnet-1"}
+	v.ExpectedSecurityGroupIds = []string{"sg-1"}
+	v.ExpectedPrivateDnsName = "api.foo.example.com"
+	v.ResolverAddr = startFakeDNSResolver(t, "10.0.0.10")
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("expected a valid result, got %s", result.Error())
+	}
+}
+
+func TestVpcEndpointValidateHostedZoneMissingRecord(t *testing.T) {
+	endpoint := availableTestVpcEndpoint()
+	hive := &fakeHiveEc2Api{
+		describeVpcEndpointsOutput: &ec2.DescribeVpcEndpointsOutput{
+			VpcEndpoints: []types.VpcEndpoint{endpoint},
+		},
+		describeNetworkInterfacesOutput: &ec2.DescribeNetworkInterfacesOutput{
+			NetworkInterfaces: []types.NetworkInterface{
+				{PrivateIpAddress: aws.String("10.0.0.10")},
+			},
+		},
+	}
+	r53 := &fakeRoute53Api{listResourceRecordSetsOutput: &route53.ListResourceRecordSetsOutput{}}
+
+	v := newTestVpcEndpoint(hive, r53)
+	v.APIURL = "https://api.foo.example.com:6443"
+	v.ResolverAddr = startFakeDNSResolver(t, "10.0.0.10")
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when the private hosted zone has no record for the API host")
+	}
+}
+
+func TestApiURLHostname(t *testing.T) {
+	cases := []struct {
+		apiURL  string
+		want    string
+		wantErr bool
+	}{
+		{apiURL: "https://api.foo.example.com:6443", want: "api.foo.example.com"},
+		{apiURL: "https://api.foo.example.com", want: "api.foo.example.com"},
+		{apiURL: "not a url", wantErr: true},
+		{apiURL: "", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := apiURLHostname(c.apiURL)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("apiURLHostname(%q): expected an error, got %q", c.apiURL, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("apiURLHostname(%q): unexpected error: %v", c.apiURL, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("apiURLHostname(%q) = %q, want %q", c.apiURL, got, c.want)
+		}
+	}
+}
+
+func TestAnyIntersect(t *testing.T) {
+	cases := []struct {
+		a, b []string
+		want bool
+	}{
+		{a: []string{"1.1.1.1", "2.2.2.2"}, b: []string{"2.2.2.2"}, want: true},
+		{a: []string{"1.1.1.1"}, b: []string{"2.2.2.2"}, want: false},
+		{a: nil, b: []string{"2.2.2.2"}, want: false},
+		{a: []string{"1.1.1.1"}, b: nil, want: false},
+	}
+
+	for _, c := range cases {
+		if got := anyIntersect(c.a, c.b); got != c.want {
+			t.Errorf("anyIntersect(%v, %v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	if !contains([]string{"a", "b"}, "b") {
+		t.Error("expected contains to find \"b\"")
+	}
+	if contains([]string{"a", "b"}, "c") {
+		t.Error("expected contains to not find \"c\"")
+	}
+	if contains(nil, "a") {
+		t.Error("expected contains(nil, ...) to be false")
+	}
+}
@@ -4,42 +4,95 @@ import (
 	"context"
 	"errors"
 	"fmt"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"go.uber.org/zap"
+
+	"github.com/openshift/mirrosa/pkg/tags"
 )
 
 const vpceServiceDescription = "A PrivateLink ROSA cluster has a VPC Endpoint Service which allows Hive to connect" +
 	" to the cluster over AWS' internal network (PrivateLink), used for things like backplane and SyncSets."
 
+// vpcEndpointConnectionStates are every state a VPC Endpoint connection can
+// be in, per the EC2 API. Earlier versions of this check only looked at
+// "available", which meant a connection stuck in "pendingAcceptance" or
+// "failed" was silently invisible.
+var vpcEndpointConnectionStates = []string{"pendingAcceptance", "available", "rejected", "failed", "deleting"}
+
 var _ Component = &VpcEndpointService{}
+var _ Remediator = &VpcEndpointService{}
 
+// VpcEndpointService is the service-side (cluster-account-owned) half of the
+// PrivateLink connection between Hive and a ROSA cluster. See VpcEndpoint
+// for the client-side (Hive-account-owned) half.
 type VpcEndpointService struct {
 	log         *zap.SugaredLogger
 	InfraName   string
 	PrivateLink bool
 
+	// HiveAccountID is the AWS account ID Validate expects to find as an
+	// allowed principal on the service, and that Remediate auto-accepts
+	// pendingAcceptance connections from.
+	HiveAccountID string
+
+	// AllowRemediation is this component's own opt-in to remediation. It
+	// is independent of Client.AllowRemediation - the caller must set
+	// both to let Remediate do anything, so enabling remediation
+	// client-wide doesn't silently turn it on for every component.
+	AllowRemediation bool
+
+	// clientAllowRemediation is a snapshot of Client.AllowRemediation
+	// taken when this component was constructed. It's the other half of
+	// the two independent gates Remediate checks.
+	clientAllowRemediation bool
+
+	// RemediateDryRun, when true, makes Remediate log the AWS API calls
+	// it would make instead of making them.
+	RemediateDryRun bool
+
+	// RejectUnknownRequesters makes Remediate reject pendingAcceptance
+	// connections whose requester isn't HiveAccountID, instead of
+	// leaving them pending for an operator to look at.
+	RejectUnknownRequesters bool
+
+	// ServiceId is populated by Validate once the VPC Endpoint Service
+	// has been found, so that later Components (and Remediate) don't
+	// have to re-discover it.
+	ServiceId string
+
+	// PrivateDnsName and BaseEndpointDnsNames are populated by Validate
+	// from the service's configuration, so that VpcEndpoint can cross-
+	// check the DNS names it sees on the client-side endpoint against
+	// what the service actually advertises.
+	PrivateDnsName       string
+	BaseEndpointDnsNames []string
+
 	Ec2Client Ec2AwsApi
 }
 
-func (c *Client) NewVpcEndpointService() VpcEndpointService {
-	return VpcEndpointService{
-		log:         c.log,
-		InfraName:   c.ClusterInfo.InfraName,
-		PrivateLink: c.Cluster.AWS().PrivateLink(),
-		Ec2Client:   ec2.NewFromConfig(c.AwsConfig),
+func (c *Client) NewVpcEndpointService() *VpcEndpointService {
+	return &VpcEndpointService{
+		log:                    c.log,
+		InfraName:              c.ClusterInfo.InfraName,
+		PrivateLink:            c.Cluster.AWS().PrivateLink(),
+		HiveAccountID:          c.HiveAccountID,
+		clientAllowRemediation: c.AllowRemediation,
+		Ec2Client:              ec2.NewFromConfig(c.AwsConfig),
 	}
 }
 
-func (v VpcEndpointService) Validate(ctx context.Context) error {
+func (v *VpcEndpointService) Validate(ctx context.Context) (ComponentResult, error) {
+	result := NewComponentResult(v.FilterValue())
+
 	// non-PrivateLink clusters do not have a VPC Endpoint Service
 	if !v.PrivateLink {
-		return nil
+		return result, nil
 	}
 
 	v.log.Info("searching for VPC Endpoint Service")
-	var serviceId string
 	resp, err := v.Ec2Client.DescribeVpcEndpointServices(ctx, &ec2.DescribeVpcEndpointServicesInput{
 		Filters: []types.Filter{
 			{
@@ -53,45 +106,196 @@ func (v VpcEndpointService) Validate(ctx context.Context) error {
 		},
 	})
 	if err != nil {
-		return err
+		return result, err
 	}
 
 	switch len(resp.ServiceDetails) {
 	case 0:
-		return errors.New("no VPC Endpoint Services found for PrivateLink cluster")
+		result.AddError(errors.New("no VPC Endpoint Services found for PrivateLink cluster"))
+		return result, nil
 	case 1:
 		v.log.Infof("found VPC Endpoint Service: %s", *resp.ServiceDetails[0].ServiceId)
-		serviceId = *resp.ServiceDetails[0].ServiceId
+		v.ServiceId = *resp.ServiceDetails[0].ServiceId
+		if diag := tags.ValidateTags(tags.KindVpcEndpointService, ec2TagsToMap(resp.ServiceDetails[0].Tags), v.InfraName); !diag.Valid() {
+			result.AddError(diag)
+		}
 	default:
-		return errors.New("multiple VPC Endpoint Services found for PrivateLink cluster")
+		result.AddError(errors.New("multiple VPC Endpoint Services found for PrivateLink cluster"))
+		return result, nil
 	}
 
-	v.log.Infof("validating VPC Endpoint Service: %s", *resp.ServiceDetails[0].ServiceId)
+	v.log.Infof("validating VPC Endpoint Service: %s", v.ServiceId)
 	cxResp, err := v.Ec2Client.DescribeVpcEndpointConnections(ctx, &ec2.DescribeVpcEndpointConnectionsInput{
 		Filters: []types.Filter{
 			{
 				Name:   aws.String("service-id"),
-				Values: []string{serviceId},
+				Values: []string{v.ServiceId},
 			},
 			{
 				Name:   aws.String("vpc-endpoint-state"),
-				Values: []string{"available"},
+				Values: vpcEndpointConnectionStates,
 			},
 		},
 	})
 	if err != nil {
-		return err
+		return result, err
 	}
 
-	switch len(cxResp.VpcEndpointConnections) {
+	byState := make(map[types.State][]string)
+	for _, cx := range cxResp.VpcEndpointConnections {
+		byState[cx.VpcEndpointState] = append(byState[cx.VpcEndpointState], aws.ToString(cx.VpcEndpointId))
+	}
+	for state, ids := range byState {
+		v.log.Infof("VPC Endpoint connections for %s in state %q: %v", v.ServiceId, state, ids)
+	}
+
+	switch len(byState[types.StateAvailable]) {
 	case 0:
-		return fmt.Errorf("no available VPC Endpoint connections found for %s", serviceId)
+		result.AddError(fmt.Errorf("no available VPC Endpoint connections found for %s, connections by state: %v", v.ServiceId, byState))
 	case 1:
-		v.log.Infof("found accepted VPC Endpoint connection for %s", serviceId)
-		return nil
+		v.log.Infof("found accepted VPC Endpoint connection for %s", v.ServiceId)
 	default:
-		return fmt.Errorf("multiple available VPC Endpoint connections found for %s", serviceId)
+		result.AddError(fmt.Errorf("multiple available VPC Endpoint connections found for %s", v.ServiceId))
+	}
+
+	result.AddError(v.validateAcceptanceRequired(ctx))
+	result.AddError(v.validatePermissions(ctx))
+
+	return result, nil
+}
+
+// validateAcceptanceRequired confirms that the VPC Endpoint Service requires
+// manual (or mirrosa-Remediate'd) acceptance of connection requests, which
+// is what ROSA expects - it's what stops an arbitrary AWS account from
+// connecting to the cluster's API server.
+func (v *VpcEndpointService) validateAcceptanceRequired(ctx context.Context) error {
+	resp, err := v.Ec2Client.DescribeVpcEndpointServiceConfigurations(ctx, &ec2.DescribeVpcEndpointServiceConfigurationsInput{
+		ServiceIds: []string{v.ServiceId},
+	})
+	if err != nil {
+		return err
 	}
+	if len(resp.ServiceConfigurations) != 1 {
+		return fmt.Errorf("expected exactly one VPC Endpoint Service configuration for %s, got %d", v.ServiceId, len(resp.ServiceConfigurations))
+	}
+
+	cfg := resp.ServiceConfigurations[0]
+	v.PrivateDnsName = aws.ToString(cfg.PrivateDnsName)
+	for _, n := range cfg.BaseEndpointDnsNames {
+		v.BaseEndpointDnsNames = append(v.BaseEndpointDnsNames, n)
+	}
+
+	if !aws.ToBool(cfg.AcceptanceRequired) {
+		return fmt.Errorf("VPC Endpoint Service %s does not require acceptance, expected AcceptanceRequired=true", v.ServiceId)
+	}
+
+	return nil
+}
+
+// validatePermissions confirms that the Hive account is allowed to connect
+// to the VPC Endpoint Service, and that no other principal is.
+func (v *VpcEndpointService) validatePermissions(ctx context.Context) error {
+	resp, err := v.Ec2Client.DescribeVpcEndpointServicePermissions(ctx, &ec2.DescribeVpcEndpointServicePermissionsInput{
+		ServiceId: aws.String(v.ServiceId),
+	})
+	if err != nil {
+		return err
+	}
+
+	expectedPrincipal := fmt.Sprintf("arn:aws:iam::%s:root", v.HiveAccountID)
+	var found bool
+	var unexpected []string
+	for _, p := range resp.AllowedPrincipals {
+		principal := aws.ToString(p.Principal)
+		if principal == expectedPrincipal {
+			found = true
+			continue
+		}
+		unexpected = append(unexpected, principal)
+	}
+
+	if !found {
+		return fmt.Errorf("Hive account %s is not an allowed principal on VPC Endpoint Service %s", v.HiveAccountID, v.ServiceId)
+	}
+	if len(unexpected) > 0 {
+		return fmt.Errorf("unexpected allowed principal(s) on VPC Endpoint Service %s: %v", v.ServiceId, unexpected)
+	}
+	return nil
+}
+
+// Remediate accepts pendingAcceptance VPC Endpoint connections initiated by
+// the known Hive account, and optionally rejects ones initiated by anyone
+// else. It requires both the Client-level and component-level remediation
+// opt-ins, and when RemediateDryRun is set it only logs the calls it would
+// make. Validate must have run first so ServiceId is populated.
+func (v *VpcEndpointService) Remediate(ctx context.Context) error {
+	if !v.clientAllowRemediation {
+		return errors.New("remediation is not enabled for this Client")
+	}
+	if !v.AllowRemediation {
+		return errors.New("remediation is not enabled for VpcEndpointService")
+	}
+	if v.ServiceId == "" {
+		return errors.New("cannot remediate VpcEndpointService before Validate has discovered a ServiceId")
+	}
+
+	cxResp, err := v.Ec2Client.DescribeVpcEndpointConnections(ctx, &ec2.DescribeVpcEndpointConnectionsInput{
+		Filters: []types.Filter{
+			{
+				Name:   aws.String("service-id"),
+				Values: []string{v.ServiceId},
+			},
+			{
+				Name:   aws.String("vpc-endpoint-state"),
+				Values: []string{"pendingAcceptance"},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var toAccept, toReject []string
+	for _, cx := range cxResp.VpcEndpointConnections {
+		id := aws.ToString(cx.VpcEndpointId)
+		if aws.ToString(cx.VpcEndpointOwner) == v.HiveAccountID {
+			toAccept = append(toAccept, id)
+		} else {
+			toReject = append(toReject, id)
+		}
+	}
+
+	if len(toAccept) > 0 {
+		if v.RemediateDryRun {
+			v.log.Infof("dry run: would accept VPC Endpoint connections %v for %s", toAccept, v.ServiceId)
+		} else {
+			v.log.Infof("accepting VPC Endpoint connections %v for %s", toAccept, v.ServiceId)
+			if _, err := v.Ec2Client.AcceptVpcEndpointConnections(ctx, &ec2.AcceptVpcEndpointConnectionsInput{
+				ServiceId:      aws.String(v.ServiceId),
+				VpcEndpointIds: toAccept,
+			}); err != nil {
+				return fmt.Errorf("accepting VPC Endpoint connections %v: %w", toAccept, err)
+			}
+		}
+	}
+
+	if len(toReject) > 0 && v.RejectUnknownRequesters {
+		if v.RemediateDryRun {
+			v.log.Infof("dry run: would reject VPC Endpoint connections %v for %s from unexpected requesters", toReject, v.ServiceId)
+		} else {
+			v.log.Infof("rejecting VPC Endpoint connections %v for %s from unexpected requesters", toReject, v.ServiceId)
+			if _, err := v.Ec2Client.RejectVpcEndpointConnections(ctx, &ec2.RejectVpcEndpointConnectionsInput{
+				ServiceId:      aws.String(v.ServiceId),
+				VpcEndpointIds: toReject,
+			}); err != nil {
+				return fmt.Errorf("rejecting VPC Endpoint connections %v: %w", toReject, err)
+			}
+		}
+	} else if len(toReject) > 0 {
+		v.log.Infof("found %d pendingAcceptance VPC Endpoint connection(s) from unexpected requesters, leaving them pending: %v", len(toReject), toReject)
+	}
+
+	return nil
 }
 
 func (v VpcEndpointService) Documentation() string {
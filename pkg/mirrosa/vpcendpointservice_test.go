@@ -0,0 +1,350 @@
+package mirrosa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/zap"
+)
+
+// fakeEc2Api embeds Ec2AwsApi so tests only need to implement the methods
+// they actually exercise; calling anything else panics on the nil
+// embedded interface, which is fine since those calls aren't expected.
+type fakeEc2Api struct {
+	Ec2AwsApi
+
+	describeVpcEndpointServicesOutput              *ec2.DescribeVpcEndpointServicesOutput
+	describeVpcEndpointServiceConfigurationsOutput *ec2.DescribeVpcEndpointServiceConfigurationsOutput
+	describeVpcEndpointServicePermissionsOutput    *ec2.DescribeVpcEndpointServicePermissionsOutput
+	describeVpcEndpointConnectionsOutput           *ec2.DescribeVpcEndpointConnectionsOutput
+	acceptedVpcEndpointIds                         []string
+	rejectedVpcEndpointIds                         []string
+}
+
+func (f *fakeEc2Api) DescribeVpcEndpointServices(_ context.Context, _ *ec2.DescribeVpcEndpointServicesInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcEndpointServicesOutput, error) {
+	if f.describeVpcEndpointServicesOutput == nil {
+		return &ec2.DescribeVpcEndpointServicesOutput{}, nil
+	}
+	return f.describeVpcEndpointServicesOutput, nil
+}
+
+func (f *fakeEc2Api) DescribeVpcEndpointServiceConfigurations(_ context.Context, _ *ec2.DescribeVpcEndpointServiceConfigurationsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcEndpointServiceConfigurationsOutput, error) {
+	if f.describeVpcEndpointServiceConfigurationsOutput == nil {
+		return &ec2.DescribeVpcEndpointServiceConfigurationsOutput{}, nil
+	}
+	return f.describeVpcEndpointServiceConfigurationsOutput, nil
+}
+
+func (f *fakeEc2Api) DescribeVpcEndpointServicePermissions(_ context.Context, _ *ec2.DescribeVpcEndpointServicePermissionsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcEndpointServicePermissionsOutput, error) {
+	if f.describeVpcEndpointServicePermissionsOutput == nil {
+		return &ec2.DescribeVpcEndpointServicePermissionsOutput{}, nil
+	}
+	return f.describeVpcEndpointServicePermissionsOutput, nil
+}
+
+func (f *fakeEc2Api) DescribeVpcEndpointConnections(_ context.Context, _ *ec2.DescribeVpcEndpointConnectionsInput, _ ...func(*ec2.Options)) (*ec2.DescribeVpcEndpointConnectionsOutput, error) {
+	if f.describeVpcEndpointConnectionsOutput == nil {
+		return &ec2.DescribeVpcEndpointConnectionsOutput{}, nil
+	}
+	return f.describeVpcEndpointConnectionsOutput, nil
+}
+
+func (f *fakeEc2Api) AcceptVpcEndpointConnections(_ context.Context, in *ec2.AcceptVpcEndpointConnectionsInput, _ ...func(*ec2.Options)) (*ec2.AcceptVpcEndpointConnectionsOutput, error) {
+	f.acceptedVpcEndpointIds = in.VpcEndpointIds
+	return &ec2.AcceptVpcEndpointConnectionsOutput{}, nil
+}
+
+func (f *fakeEc2Api) RejectVpcEndpointConnections(_ context.Context, in *ec2.RejectVpcEndpointConnectionsInput, _ ...func(*ec2.Options)) (*ec2.RejectVpcEndpointConnectionsOutput, error) {
+	f.rejectedVpcEndpointIds = in.VpcEndpointIds
+	return &ec2.RejectVpcEndpointConnectionsOutput{}, nil
+}
+
+func newTestVpcEndpointService(ec2Client *fakeEc2Api) *VpcEndpointService {
+	return &VpcEndpointService{
+		log:                    zap.NewNop().Sugar(),
+		InfraName:              "foo",
+		PrivateLink:            true,
+		HiveAccountID:          "111111111111",
+		ServiceId:              "vpce-svc-test",
+		clientAllowRemediation: true,
+		AllowRemediation:       true,
+		Ec2Client:              ec2Client,
+	}
+}
+
+// availableTestServiceDetail returns a ServiceDetail with the correct tags.
+// Together with availableTestServiceConfiguration (AcceptanceRequired set)
+// and availableTestPermissions (a matching Hive principal), it passes every
+// Validate check.
+func availableTestServiceDetail() types.ServiceDetail {
+	return types.ServiceDetail{
+		ServiceId: aws.String("vpce-svc-test"),
+		Tags: []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String("foo-vpc-endpoint-service")},
+			{Key: aws.String("red-hat-managed"), Value: aws.String("true")},
+			{Key: aws.String("hive.openshift.io/private-link-access-for"), Value: aws.String("foo")},
+			{Key: aws.String("kubernetes.io/cluster/foo"), Value: aws.String("owned")},
+		},
+	}
+}
+
+func availableTestServiceConfiguration() types.ServiceConfiguration {
+	return types.ServiceConfiguration{
+		AcceptanceRequired:   aws.Bool(true),
+		PrivateDnsName:       aws.String("api.foo.example.com"),
+		BaseEndpointDnsNames: []string{"vpce-svc-test.ec2.amazonaws.com"},
+	}
+}
+
+func availableTestPermissions() *ec2.DescribeVpcEndpointServicePermissionsOutput {
+	return &ec2.DescribeVpcEndpointServicePermissionsOutput{
+		AllowedPrincipals: []types.AllowedPrincipal{
+			{Principal: aws.String("arn:aws:iam::111111111111:root")},
+		},
+	}
+}
+
+func TestVpcEndpointServiceValidateNoPrivateLink(t *testing.T) {
+	v := newTestVpcEndpointService(&fakeEc2Api{})
+	v.PrivateLink = false
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("expected a valid result for a non-PrivateLink cluster, got %s", result.Error())
+	}
+}
+
+func TestVpcEndpointServiceValidateNoServicesFound(t *testing.T) {
+	v := newTestVpcEndpointService(&fakeEc2Api{})
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when no VPC Endpoint Services are found")
+	}
+}
+
+func TestVpcEndpointServiceValidateMultipleServicesFound(t *testing.T) {
+	fake := &fakeEc2Api{
+		describeVpcEndpointServicesOutput: &ec2.DescribeVpcEndpointServicesOutput{
+			ServiceDetails: []types.ServiceDetail{availableTestServiceDetail(), availableTestServiceDetail()},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when multiple VPC Endpoint Services are found")
+	}
+}
+
+func TestVpcEndpointServiceValidateTagMismatch(t *testing.T) {
+	detail := availableTestServiceDetail()
+	detail.Tags = []types.Tag{{Key: aws.String("Name"), Value: aws.String("foo-vpc-endpoint-service")}}
+	fake := &fakeEc2Api{
+		describeVpcEndpointServicesOutput: &ec2.DescribeVpcEndpointServicesOutput{
+			ServiceDetails: []types.ServiceDetail{detail},
+		},
+		describeVpcEndpointServiceConfigurationsOutput: &ec2.DescribeVpcEndpointServiceConfigurationsOutput{
+			ServiceConfigurations: []types.ServiceConfiguration{availableTestServiceConfiguration()},
+		},
+		describeVpcEndpointServicePermissionsOutput: availableTestPermissions(),
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-1"), VpcEndpointState: types.StateAvailable},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when the service is missing required tags")
+	}
+}
+
+func TestVpcEndpointServiceValidatePermissionsUnexpectedPrincipal(t *testing.T) {
+	fake := &fakeEc2Api{
+		describeVpcEndpointServicesOutput: &ec2.DescribeVpcEndpointServicesOutput{
+			ServiceDetails: []types.ServiceDetail{availableTestServiceDetail()},
+		},
+		describeVpcEndpointServiceConfigurationsOutput: &ec2.DescribeVpcEndpointServiceConfigurationsOutput{
+			ServiceConfigurations: []types.ServiceConfiguration{availableTestServiceConfiguration()},
+		},
+		describeVpcEndpointServicePermissionsOutput: &ec2.DescribeVpcEndpointServicePermissionsOutput{
+			AllowedPrincipals: []types.AllowedPrincipal{
+				{Principal: aws.String("arn:aws:iam::111111111111:root")},
+				{Principal: aws.String("arn:aws:iam::999999999999:root")},
+			},
+		},
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-1"), VpcEndpointState: types.StateAvailable},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when an unexpected principal is allowed")
+	}
+}
+
+func TestVpcEndpointServiceValidateAcceptanceNotRequired(t *testing.T) {
+	cfg := availableTestServiceConfiguration()
+	cfg.AcceptanceRequired = aws.Bool(false)
+	fake := &fakeEc2Api{
+		describeVpcEndpointServicesOutput: &ec2.DescribeVpcEndpointServicesOutput{
+			ServiceDetails: []types.ServiceDetail{availableTestServiceDetail()},
+		},
+		describeVpcEndpointServiceConfigurationsOutput: &ec2.DescribeVpcEndpointServiceConfigurationsOutput{
+			ServiceConfigurations: []types.ServiceConfiguration{cfg},
+		},
+		describeVpcEndpointServicePermissionsOutput: availableTestPermissions(),
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-1"), VpcEndpointState: types.StateAvailable},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected invalid result when AcceptanceRequired is false")
+	}
+}
+
+func TestVpcEndpointServiceValidateSuccess(t *testing.T) {
+	fake := &fakeEc2Api{
+		describeVpcEndpointServicesOutput: &ec2.DescribeVpcEndpointServicesOutput{
+			ServiceDetails: []types.ServiceDetail{availableTestServiceDetail()},
+		},
+		describeVpcEndpointServiceConfigurationsOutput: &ec2.DescribeVpcEndpointServiceConfigurationsOutput{
+			ServiceConfigurations: []types.ServiceConfiguration{availableTestServiceConfiguration()},
+		},
+		describeVpcEndpointServicePermissionsOutput: availableTestPermissions(),
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-1"), VpcEndpointState: types.StateAvailable},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+	v.ServiceId = ""
+
+	result, err := v.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("expected a valid result, got %s", result.Error())
+	}
+	if v.ServiceId != "vpce-svc-test" {
+		t.Errorf("expected Validate to populate ServiceId, got %q", v.ServiceId)
+	}
+	if v.PrivateDnsName != "api.foo.example.com" {
+		t.Errorf("expected Validate to populate PrivateDnsName, got %q", v.PrivateDnsName)
+	}
+	if len(v.BaseEndpointDnsNames) != 1 || v.BaseEndpointDnsNames[0] != "vpce-svc-test.ec2.amazonaws.com" {
+		t.Errorf("expected Validate to populate BaseEndpointDnsNames, got %v", v.BaseEndpointDnsNames)
+	}
+}
+
+func TestRemediateAcceptsKnownHiveConnections(t *testing.T) {
+	fake := &fakeEc2Api{
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-1"), VpcEndpointOwner: aws.String("111111111111")},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+
+	if err := v.Remediate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.acceptedVpcEndpointIds) != 1 || fake.acceptedVpcEndpointIds[0] != "vpce-1" {
+		t.Fatalf("expected vpce-1 to be accepted, got %v", fake.acceptedVpcEndpointIds)
+	}
+	if len(fake.rejectedVpcEndpointIds) != 0 {
+		t.Fatalf("expected no rejections, got %v", fake.rejectedVpcEndpointIds)
+	}
+}
+
+func TestRemediateLeavesUnknownRequestersPendingByDefault(t *testing.T) {
+	fake := &fakeEc2Api{
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-2"), VpcEndpointOwner: aws.String("222222222222")},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+
+	if err := v.Remediate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.rejectedVpcEndpointIds) != 0 {
+		t.Fatalf("expected no rejections without RejectUnknownRequesters, got %v", fake.rejectedVpcEndpointIds)
+	}
+	if len(fake.acceptedVpcEndpointIds) != 0 {
+		t.Fatalf("expected no acceptances for an unknown requester, got %v", fake.acceptedVpcEndpointIds)
+	}
+}
+
+func TestRemediateRejectsUnknownRequestersWhenOptedIn(t *testing.T) {
+	fake := &fakeEc2Api{
+		describeVpcEndpointConnectionsOutput: &ec2.DescribeVpcEndpointConnectionsOutput{
+			VpcEndpointConnections: []types.VpcEndpointConnection{
+				{VpcEndpointId: aws.String("vpce-3"), VpcEndpointOwner: aws.String("222222222222")},
+			},
+		},
+	}
+	v := newTestVpcEndpointService(fake)
+	v.RejectUnknownRequesters = true
+
+	if err := v.Remediate(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fake.rejectedVpcEndpointIds) != 1 || fake.rejectedVpcEndpointIds[0] != "vpce-3" {
+		t.Fatalf("expected vpce-3 to be rejected, got %v", fake.rejectedVpcEndpointIds)
+	}
+}
+
+func TestRemediateRequiresBothGates(t *testing.T) {
+	fake := &fakeEc2Api{}
+
+	v := newTestVpcEndpointService(fake)
+	v.clientAllowRemediation = false
+	if err := v.Remediate(context.Background()); err == nil {
+		t.Fatal("expected an error when the Client-level gate is off")
+	}
+
+	v = newTestVpcEndpointService(fake)
+	v.AllowRemediation = false
+	if err := v.Remediate(context.Background()); err == nil {
+		t.Fatal("expected an error when the component-level gate is off")
+	}
+}
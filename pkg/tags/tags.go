@@ -0,0 +1,135 @@
+// Package tags defines the ROSA/Hive tag contract that AWS resources
+// backing a PrivateLink cluster are expected to carry, and validates actual
+// resource tags against it. It exists so every Component checks tags the
+// same way, instead of each one hand-rolling its own filter against a
+// couple of tags it happens to care about.
+package tags
+
+import "fmt"
+
+// Kind identifies the category of AWS resource being tag-checked, since the
+// expected tag set differs slightly by resource.
+type Kind string
+
+const (
+	KindVpcEndpointService Kind = "VpcEndpointService"
+	KindVpcEndpoint        Kind = "VpcEndpoint"
+)
+
+// baseTags are the tags every PrivateLink resource mirrosa looks at is
+// expected to carry, keyed by tag name to a function deriving its expected
+// value from the cluster's infra name.
+var baseTags = map[string]func(infraName string) string{
+	"red-hat-managed": func(string) string { return "true" },
+	"hive.openshift.io/private-link-access-for": func(infraName string) string { return infraName },
+}
+
+// overrides adds or replaces expected tags for specific resource Kinds on
+// top of baseTags, e.g. the per-resource "Name" tag which differs in
+// suffix by resource kind.
+var overrides = map[Kind]map[string]func(infraName string) string{
+	KindVpcEndpointService: {
+		"Name": func(infraName string) string { return infraName + "-vpc-endpoint-service" },
+	},
+	KindVpcEndpoint: {
+		"Name": func(infraName string) string { return infraName + "-vpc-endpoint" },
+	},
+}
+
+// Diagnostics is the structured result of comparing a resource's actual
+// tags against its expected tag set.
+type Diagnostics struct {
+	Kind Kind
+
+	// Missing holds expected tags that were not present at all.
+	Missing map[string]string
+
+	// Mismatched holds expected tags that were present with the wrong
+	// value, keyed by tag name to (expected, actual).
+	Mismatched map[string]Mismatch
+
+	// Extraneous holds tags present on the resource that aren't part of
+	// the expected contract. These are not failures on their own - they
+	// just aren't validated - but are surfaced so drift is visible.
+	Extraneous []string
+}
+
+// Mismatch is the expected vs. actual value of a tag whose value didn't
+// match what was expected.
+type Mismatch struct {
+	Expected string
+	Actual   string
+}
+
+// Valid reports whether the resource satisfies the tag contract, i.e. has
+// no missing or mismatched tags. Extraneous tags don't affect validity.
+func (d Diagnostics) Valid() bool {
+	return len(d.Missing) == 0 && len(d.Mismatched) == 0
+}
+
+// Error implements the error interface so Diagnostics can be handled
+// anywhere a plain error was expected, matching mirrosa.ComponentResult.
+func (d Diagnostics) Error() string {
+	if d.Valid() {
+		return ""
+	}
+	msg := fmt.Sprintf("%s tag validation failed:", d.Kind)
+	for tag, expected := range d.Missing {
+		msg += fmt.Sprintf("\n  - missing tag %q (expected %q)", tag, expected)
+	}
+	for tag, mismatch := range d.Mismatched {
+		msg += fmt.Sprintf("\n  - tag %q is %q, expected %q", tag, mismatch.Actual, mismatch.Expected)
+	}
+	return msg
+}
+
+// expectedTags builds the full expected tag set for kind, given the
+// cluster's infra name.
+func expectedTags(kind Kind, infraName string) map[string]string {
+	expected := map[string]string{
+		"kubernetes.io/cluster/" + infraName: "owned",
+	}
+	for tag, valueFn := range baseTags {
+		expected[tag] = valueFn(infraName)
+	}
+	for tag, valueFn := range overrides[kind] {
+		expected[tag] = valueFn(infraName)
+	}
+	return expected
+}
+
+// ValidateTags compares actual (as returned by a Describe* call's Tags
+// field, flattened to a map) against the tag contract for kind, given the
+// cluster's infra name.
+func ValidateTags(kind Kind, actual map[string]string, infraName string) Diagnostics {
+	diag := Diagnostics{Kind: kind}
+
+	expected := expectedTags(kind, infraName)
+	seen := make(map[string]bool, len(expected))
+
+	for tag, expectedValue := range expected {
+		actualValue, ok := actual[tag]
+		seen[tag] = true
+		if !ok {
+			if diag.Missing == nil {
+				diag.Missing = make(map[string]string)
+			}
+			diag.Missing[tag] = expectedValue
+			continue
+		}
+		if actualValue != expectedValue {
+			if diag.Mismatched == nil {
+				diag.Mismatched = make(map[string]Mismatch)
+			}
+			diag.Mismatched[tag] = Mismatch{Expected: expectedValue, Actual: actualValue}
+		}
+	}
+
+	for tag := range actual {
+		if !seen[tag] {
+			diag.Extraneous = append(diag.Extraneous, tag)
+		}
+	}
+
+	return diag
+}
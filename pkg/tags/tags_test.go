@@ -0,0 +1,90 @@
+package tags
+
+import "testing"
+
+func TestValidateTagsValid(t *testing.T) {
+	actual := map[string]string{
+		"Name":            "foo-vpc-endpoint-service",
+		"red-hat-managed": "true",
+		"hive.openshift.io/private-link-access-for": "foo",
+		"kubernetes.io/cluster/foo":                 "owned",
+	}
+
+	diag := ValidateTags(KindVpcEndpointService, actual, "foo")
+	if !diag.Valid() {
+		t.Fatalf("expected valid, got %s", diag.Error())
+	}
+	if len(diag.Extraneous) != 0 {
+		t.Fatalf("expected no extraneous tags, got %v", diag.Extraneous)
+	}
+}
+
+func TestValidateTagsMissing(t *testing.T) {
+	actual := map[string]string{
+		"Name": "foo-vpc-endpoint-service",
+	}
+
+	diag := ValidateTags(KindVpcEndpointService, actual, "foo")
+	if diag.Valid() {
+		t.Fatal("expected invalid due to missing tags")
+	}
+	for _, tag := range []string{"red-hat-managed", "hive.openshift.io/private-link-access-for", "kubernetes.io/cluster/foo"} {
+		if _, ok := diag.Missing[tag]; !ok {
+			t.Errorf("expected %q to be reported missing", tag)
+		}
+	}
+}
+
+func TestValidateTagsMismatched(t *testing.T) {
+	actual := map[string]string{
+		"Name":            "foo-vpc-endpoint-service",
+		"red-hat-managed": "false",
+		"hive.openshift.io/private-link-access-for": "bar",
+		"kubernetes.io/cluster/foo":                 "owned",
+	}
+
+	diag := ValidateTags(KindVpcEndpointService, actual, "foo")
+	if diag.Valid() {
+		t.Fatal("expected invalid due to mismatched tags")
+	}
+	if m, ok := diag.Mismatched["red-hat-managed"]; !ok || m.Expected != "true" || m.Actual != "false" {
+		t.Errorf("expected red-hat-managed mismatch true/false, got %+v", diag.Mismatched["red-hat-managed"])
+	}
+	if m, ok := diag.Mismatched["hive.openshift.io/private-link-access-for"]; !ok || m.Expected != "foo" || m.Actual != "bar" {
+		t.Errorf("expected private-link-access-for mismatch foo/bar, got %+v", diag.Mismatched["hive.openshift.io/private-link-access-for"])
+	}
+}
+
+func TestValidateTagsExtraneous(t *testing.T) {
+	actual := map[string]string{
+		"Name":            "foo-vpc-endpoint-service",
+		"red-hat-managed": "true",
+		"hive.openshift.io/private-link-access-for": "foo",
+		"kubernetes.io/cluster/foo":                 "owned",
+		"some-other-tag":                            "value",
+	}
+
+	diag := ValidateTags(KindVpcEndpointService, actual, "foo")
+	if !diag.Valid() {
+		t.Fatalf("extraneous tags should not affect validity, got %s", diag.Error())
+	}
+	if len(diag.Extraneous) != 1 || diag.Extraneous[0] != "some-other-tag" {
+		t.Fatalf("expected extraneous [some-other-tag], got %v", diag.Extraneous)
+	}
+}
+
+func TestValidateTagsPerKindNameOverride(t *testing.T) {
+	actual := map[string]string{
+		"Name":            "foo-vpc-endpoint",
+		"red-hat-managed": "true",
+		"hive.openshift.io/private-link-access-for": "foo",
+		"kubernetes.io/cluster/foo":                 "owned",
+	}
+
+	if diag := ValidateTags(KindVpcEndpoint, actual, "foo"); !diag.Valid() {
+		t.Fatalf("expected valid for KindVpcEndpoint, got %s", diag.Error())
+	}
+	if diag := ValidateTags(KindVpcEndpointService, actual, "foo"); diag.Valid() {
+		t.Fatal("expected invalid for KindVpcEndpointService since Name has the wrong suffix")
+	}
+}